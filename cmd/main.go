@@ -3,6 +3,8 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"flag"
 	"fmt"
@@ -18,19 +20,30 @@ import (
 func main() {
 	// Flags and args processing.
 	var timeout time.Duration
+	var useTLS, useStartTLS bool
+	var caFile string
 	flag.DurationVar(&timeout, "timeout", 10*time.Second, "telnet [--timeout=5s] <host> <port>")
+	flag.BoolVar(&useTLS, "tls", false, "connect using implicit TLS (telnets)")
+	flag.BoolVar(&useStartTLS, "starttls", false, "upgrade to TLS in-band once the server offers START_TLS")
+	flag.StringVar(&caFile, "ca", "", "PEM file with a CA certificate to trust, in addition to the system pool")
 	flag.Parse()
 
 	args := flag.Args()
 	if len(args) != 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [--timeout=duration] <host> <port>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [--timeout=duration] [--tls] [--starttls] [--ca=file] <host> <port>\n", os.Args[0])
 		return
 	}
 
 	addr := net.JoinHostPort(args[0], args[1])
 
+	opts, err := tlsOptions(useTLS, useStartTLS, args[0], caFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "...Unable to set up TLS: %v\n", err)
+		return
+	}
+
 	// Reading from stdin, printing to stdout.
-	client := telnet.NewClient(addr, timeout, os.Stdin, os.Stdout)
+	client := telnet.NewClient(addr, timeout, os.Stdin, os.Stdout, opts...)
 
 	// Connection.
 	if err := client.Connect(); err != nil {
@@ -60,6 +73,16 @@ func main() {
 				return
 			default:
 				if err := client.Send(); err != nil {
+					// Ctrl-D: mirror BSD telnet and send EOT by half-closing the write side,
+					// without tearing down the still-running receiving goroutine.
+					if errors.Is(err, telnet.ErrEOT) {
+						if cwErr := client.CloseWrite(); cwErr != nil {
+							fmt.Fprintf(os.Stderr, "...Ctrl-D: unable to close write half: %v\n", cwErr)
+						} else {
+							fmt.Fprintf(os.Stderr, "...Ctrl-D: write half closed\n")
+						}
+						return
+					}
 					errCh <- err
 					return
 				}
@@ -97,3 +120,32 @@ func main() {
 		}
 	}
 }
+
+// tlsOptions builds the telnet.ClientOption set implied by the --tls/--starttls/--ca flags.
+func tlsOptions(useTLS, useStartTLS bool, host, caFile string) ([]telnet.ClientOption, error) {
+	if !useTLS && !useStartTLS {
+		return nil, nil
+	}
+
+	var cfg tls.Config
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	opts := []telnet.ClientOption{telnet.WithTLS(&cfg), telnet.WithServerName(host)}
+	if useStartTLS {
+		opts = append(opts, telnet.WithStartTLS())
+	}
+	return opts, nil
+}
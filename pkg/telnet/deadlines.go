@@ -0,0 +1,219 @@
+package telnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// cancelDeadline is set on the connection by watchContext when ctx is canceled, to unblock
+// whatever Read/Write is currently in flight. It is in the past for any real clock, so the
+// pending I/O fails immediately with a timeout-shaped error.
+var cancelDeadline = time.Unix(1, 0)
+
+// watchContext starts a goroutine that forces conn's deadline into the past as soon as ctx is
+// done, unblocking any in-flight Read/Write on conn that net.Conn itself can't be canceled
+// with. The returned stop function must be called once the operation it guards has finished; it
+// blocks until the goroutine has exited, so the caller can safely reset conn's deadline right
+// after calling it without racing a late SetDeadline(cancelDeadline). If the watcher fired, stop
+// also clears both the read and the write deadline, so the past deadline does not linger into
+// later calls in either direction.
+func watchContext(ctx context.Context, conn net.Conn) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	fired := false
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			_ = conn.SetDeadline(cancelDeadline)
+			fired = true
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+		if fired {
+			_ = conn.SetDeadline(time.Time{})
+		}
+	}
+}
+
+// SendContext sends data received from the input stream to the server, honoring ctx's deadline
+// for the write and unblocking immediately if ctx is canceled mid-write.
+func (c *client) SendContext(ctx context.Context) error {
+	c.mu.RLock()
+	if c.conn == nil || c.in == nil {
+		c.mu.RUnlock()
+		return fmt.Errorf("nil parameter received: connection=%v, input_stream=%v", c.conn == nil, c.in == nil)
+	}
+	if c.keepAliveErr != nil {
+		err := c.keepAliveErr
+		c.mu.RUnlock()
+		return err
+	}
+	inReader, conn := c.inReader, c.conn
+	c.mu.RUnlock()
+
+	data, err := c.readOut(inReader)
+	if err != nil {
+		return err
+	}
+	// CTRL+D case.
+	if len(data) == 0 {
+		return ErrEOT
+	}
+
+	deadline, _ := ctx.Deadline() // Zero value clears any deadline left over from a prior call.
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return fmt.Errorf("unable to set write deadline: %w", err)
+	}
+	stop := watchContext(ctx, conn)
+	defer func() {
+		stop()
+		_ = conn.SetWriteDeadline(time.Time{})
+	}()
+
+	if err := c.writeOut(conn, escapeIAC(data)); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ReceiveContext reads data from the server and writes it to the output stream, honoring ctx's
+// deadline (combined with any WithIdleTimeout, whichever is sooner) for the read and unblocking
+// immediately if ctx is canceled mid-read.
+func (c *client) ReceiveContext(ctx context.Context) error {
+	c.mu.RLock()
+	if c.conn == nil || c.out == nil {
+		c.mu.RUnlock()
+		return fmt.Errorf("nil parameter received: connection=%v, output_stream=%v", c.conn == nil, c.out == nil)
+	}
+	if c.keepAliveErr != nil {
+		err := c.keepAliveErr
+		c.mu.RUnlock()
+		return err
+	}
+	connReader, conn, idleTimeout := c.reader, c.conn, c.idleTimeout
+	c.mu.RUnlock()
+
+	deadline, _ := readDeadline(ctx, idleTimeout) // Zero value clears any prior deadline.
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return fmt.Errorf("unable to set read deadline: %w", err)
+	}
+	stop := watchContext(ctx, conn)
+	defer func() {
+		stop()
+		_ = conn.SetReadDeadline(time.Time{})
+	}()
+
+	data, err := c.readOut(connReader)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w: %w", ctx.Err(), err)
+			}
+			// The read deadline came from ctx, not the idle timeout, even if ctx's own timer
+			// hasn't fired yet.
+			if ctxDeadline, ok := ctx.Deadline(); ok && !deadline.Before(ctxDeadline) {
+				return fmt.Errorf("%w: %w", context.DeadlineExceeded, err)
+			}
+			return ErrIdleTimeout
+		}
+		return err
+	}
+	c.notifyRecv()
+
+	c.mu.Lock()
+	clean, err := c.neg.process(data, c.conn)
+	pendingStartTLS := c.pendingStartTLS
+	c.pendingStartTLS = false
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if pendingStartTLS {
+		if err := c.upgradeToTLS(); err != nil {
+			return err
+		}
+	}
+
+	if len(clean) == 0 {
+		return nil
+	}
+
+	return c.writeOut(c.out, clean)
+}
+
+// readDeadline combines ctx's deadline (if any) with the idle timeout (if any), returning
+// whichever comes first.
+func readDeadline(ctx context.Context, idleTimeout time.Duration) (time.Time, bool) {
+	deadline, ok := ctx.Deadline()
+	if idleTimeout > 0 {
+		if idle := time.Now().Add(idleTimeout); !ok || idle.Before(deadline) {
+			deadline, ok = idle, true
+		}
+	}
+	return deadline, ok
+}
+
+// halfCloser and halfWriteCloser implement CloseRead/CloseWrite without hard-coding a concrete
+// connection type: *net.TCPConn satisfies both, *tls.Conn only halfWriteCloser.
+type halfCloser interface {
+	CloseRead() error
+}
+
+type halfWriteCloser interface {
+	CloseWrite() error
+}
+
+// CloseRead closes the read half of the connection without tearing down the write half, so data
+// can still be sent after nothing more is to be received. It requires the underlying connection
+// to have a CloseRead method (*net.TCPConn does; *tls.Conn does not, so after a TLS handshake
+// it returns an error).
+func (c *client) CloseRead() error {
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+
+	if conn == nil {
+		return fmt.Errorf("%w", ErrConnClosed)
+	}
+	hc, ok := conn.(halfCloser)
+	if !ok {
+		return fmt.Errorf("connection does not support half-close")
+	}
+	if err := hc.CloseRead(); err != nil {
+		return fmt.Errorf("unable to close read half: %w", err)
+	}
+	return nil
+}
+
+// CloseWrite closes the write half of the connection without tearing down the read half. Both
+// *net.TCPConn and a TLS connection support this.
+func (c *client) CloseWrite() error {
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+
+	if conn == nil {
+		return fmt.Errorf("%w", ErrConnClosed)
+	}
+	hc, ok := conn.(halfWriteCloser)
+	if !ok {
+		return fmt.Errorf("connection does not support half-close")
+	}
+	if err := hc.CloseWrite(); err != nil {
+		return fmt.Errorf("unable to close write half: %w", err)
+	}
+	return nil
+}
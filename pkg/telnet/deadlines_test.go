@@ -0,0 +1,143 @@
+package telnet
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_HalfClose(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, acceptErr := l.Accept()
+		require.NoError(t, acceptErr)
+		accepted <- conn
+	}()
+
+	c := NewClient(l.Addr().String(), 5*time.Second, io.NopCloser(&bytes.Buffer{}), &bytes.Buffer{})
+	require.NoError(t, c.Connect())
+	defer func() { _ = c.Close() }()
+
+	serverConn := <-accepted
+	defer func() { _ = serverConn.Close() }()
+
+	require.NoError(t, c.CloseWrite())
+
+	// The server side should observe EOF on read, since only the client's write half closed.
+	buf := make([]byte, 16)
+	require.NoError(t, serverConn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := serverConn.Read(buf)
+	require.Equal(t, 0, n)
+	require.ErrorIs(t, err, io.EOF)
+
+	require.NoError(t, c.CloseRead())
+}
+
+func TestClient_ReceiveContext_DeadlineExceeded(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	go func() {
+		conn, acceptErr := l.Accept()
+		require.NoError(t, acceptErr)
+		defer conn.Close()
+		time.Sleep(500 * time.Millisecond) // Never sends anything in time.
+	}()
+
+	c := NewClient(l.Addr().String(), 5*time.Second, nil, &bytes.Buffer{})
+	require.NoError(t, c.Connect())
+	defer func() { _ = c.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err = c.ReceiveContext(ctx)
+	require.Error(t, err)
+}
+
+func TestClient_ReceiveContext_CanceledMidRead(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	go func() {
+		conn, acceptErr := l.Accept()
+		require.NoError(t, acceptErr)
+		defer conn.Close()
+		time.Sleep(500 * time.Millisecond)
+	}()
+
+	c := NewClient(l.Addr().String(), 5*time.Second, nil, &bytes.Buffer{})
+	require.NoError(t, c.Connect())
+	defer func() { _ = c.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.ReceiveContext(ctx) }()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		require.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("ReceiveContext did not unblock after ctx cancellation")
+	}
+}
+
+func TestClient_ReceiveContext_DeadlineDoesNotLeakToNextCall(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, acceptErr := l.Accept()
+		require.NoError(t, acceptErr)
+		accepted <- conn
+	}()
+
+	c := NewClient(l.Addr().String(), 5*time.Second, nil, &bytes.Buffer{})
+	require.NoError(t, c.Connect())
+	defer func() { _ = c.Close() }()
+	serverConn := <-accepted
+	defer func() { _ = serverConn.Close() }()
+
+	// A canceled, deadline-bearing call sets the conn's deadline into the past via
+	// watchContext...
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.ReceiveContext(ctx) }()
+	cancel()
+	select {
+	case err := <-done:
+		require.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("ReceiveContext did not unblock after ctx cancellation")
+	}
+
+	// ...but that must not linger into a later call made with no deadline at all.
+	_, err = serverConn.Write([]byte("hello\n"))
+	require.NoError(t, err)
+
+	recvDone := make(chan error, 1)
+	go func() { recvDone <- c.Receive() }()
+
+	select {
+	case err := <-recvDone:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Receive blocked or failed due to a leaked deadline from the canceled call")
+	}
+}
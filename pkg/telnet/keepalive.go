@@ -0,0 +1,125 @@
+package telnet
+
+import (
+	"time"
+)
+
+// WithKeepAlive enables application-level keep-alive: every interval, the client sends a telnet
+// IAC NOP (or IAC AYT, see WithAYTKeepAlive) to keep a quiet link from being dropped by
+// middleboxes. Only IAC AYT reliably elicits a reply from the server, so timeout (the bounded
+// response deadline, borrowing the pattern of periodic SSH keep-alive requests) only applies to
+// the AYT variant: a bare NOP is a one-way warm-keeper and never disconnects on its own. If an
+// AYT probe goes unanswered within timeout, the client closes the connection: a Send/Receive
+// call already in flight fails with ErrConnClosed, and the next Send/Receive call returns
+// ErrKeepAliveTimeout. Pair NOP keep-alive with
+// WithIdleTimeout if dead-link detection is also needed, since NOP alone won't provide it.
+func WithKeepAlive(interval, timeout time.Duration) ClientOption {
+	return func(c *client) {
+		c.keepAliveInterval = interval
+		c.keepAliveTimeout = timeout
+	}
+}
+
+// WithAYTKeepAlive makes the keep-alive probe (enabled via WithKeepAlive) an IAC AYT instead of
+// the default IAC NOP.
+func WithAYTKeepAlive() ClientOption {
+	return func(c *client) {
+		c.aytKeepAlive = true
+	}
+}
+
+// WithIdleTimeout sets a read deadline applied on every Receive call, so a quiet link (one that
+// neither sends data nor tears down the TCP connection with a FIN/RST) surfaces ErrIdleTimeout
+// instead of hanging forever.
+func WithIdleTimeout(d time.Duration) ClientOption {
+	return func(c *client) {
+		c.idleTimeout = d
+	}
+}
+
+// keepAliveLoop sends a probe every keepAliveInterval. For the AYT variant, it also expects
+// recvNotify to fire within keepAliveTimeout afterwards; Receive() signals recvNotify whenever
+// it successfully reads from the connection. If an AYT probe goes unanswered, the connection is
+// closed and keepAliveErr is set so the next Send/Receive call surfaces ErrKeepAliveTimeout. The
+// NOP variant has no reliable reply to wait for, so it only ever writes the probe.
+func (c *client) keepAliveLoop() {
+	c.mu.RLock()
+	interval, timeout, ayt := c.keepAliveInterval, c.keepAliveTimeout, c.aytKeepAlive
+	stop, notify := c.stopKeepAlive, c.recvNotify
+	c.mu.RUnlock()
+
+	probe := []byte{iacIAC, iacNOP}
+	if ayt {
+		probe = []byte{iacIAC, iacAYT}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		c.mu.RLock()
+		conn := c.conn
+		c.mu.RUnlock()
+		if conn == nil {
+			return
+		}
+
+		if !ayt {
+			// A bare NOP elicits no response from a compliant server, so there's nothing to
+			// wait for: arming the disconnect timer here would drop a perfectly healthy but
+			// quiet link. Just keep the probe going.
+			if _, err := conn.Write(probe); err != nil {
+				return
+			}
+			continue
+		}
+
+		// Drain any activity signaled before the probe, so the wait below only reacts to
+		// what arrives after it.
+		select {
+		case <-notify:
+		default:
+		}
+
+		if _, err := conn.Write(probe); err != nil {
+			return
+		}
+
+		select {
+		case <-notify:
+			continue
+		case <-stop:
+			return
+		case <-time.After(timeout):
+			c.mu.Lock()
+			c.keepAliveErr = ErrKeepAliveTimeout
+			conn := c.conn
+			c.mu.Unlock()
+			if conn != nil {
+				_ = conn.Close()
+			}
+			return
+		}
+	}
+}
+
+// notifyRecv signals the keep-alive goroutine (if any) that data was just read from the
+// connection. It is a no-op when keep-alive isn't enabled.
+func (c *client) notifyRecv() {
+	c.mu.RLock()
+	notify := c.recvNotify
+	c.mu.RUnlock()
+	if notify == nil {
+		return
+	}
+	select {
+	case notify <- struct{}{}:
+	default:
+	}
+}
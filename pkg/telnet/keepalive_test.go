@@ -0,0 +1,92 @@
+package telnet
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_KeepAliveTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, acceptErr := l.Accept()
+		require.NoError(t, acceptErr)
+		accepted <- conn
+	}()
+
+	c := NewClient(
+		l.Addr().String(), 5*time.Second, nil, &bytes.Buffer{},
+		WithKeepAlive(20*time.Millisecond, 30*time.Millisecond), WithAYTKeepAlive(),
+	)
+	require.NoError(t, c.Connect())
+	defer func() { _ = c.Close() }()
+
+	conn := <-accepted
+	defer func() { _ = conn.Close() }()
+
+	// The server never answers the AYT probe, so the keep-alive goroutine should close the
+	// connection and surface ErrKeepAliveTimeout on the next Receive call.
+	require.Eventually(t, func() bool {
+		return errors.Is(c.Receive(), ErrKeepAliveTimeout)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestClient_KeepAliveNOP_DoesNotDisconnectQuietLink(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, acceptErr := l.Accept()
+		require.NoError(t, acceptErr)
+		accepted <- conn
+	}()
+
+	c := NewClient(
+		l.Addr().String(), 5*time.Second, nil, &bytes.Buffer{},
+		WithKeepAlive(20*time.Millisecond, 30*time.Millisecond),
+	)
+	require.NoError(t, c.Connect())
+	defer func() { _ = c.Close() }()
+
+	conn := <-accepted
+	defer func() { _ = conn.Close() }()
+
+	// The server never replies to the NOP probes, but NOP keep-alive must not arm a disconnect
+	// timer on its own: a perfectly healthy, quiet link should survive several probe intervals.
+	time.Sleep(150 * time.Millisecond)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, 2)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte{iacIAC, iacNOP}, buf[:n])
+}
+
+func TestClient_IdleTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	go func() {
+		conn, acceptErr := l.Accept()
+		require.NoError(t, acceptErr)
+		defer conn.Close()
+		time.Sleep(200 * time.Millisecond) // Stay connected, but quiet.
+	}()
+
+	c := NewClient(l.Addr().String(), 5*time.Second, nil, &bytes.Buffer{}, WithIdleTimeout(20*time.Millisecond))
+	require.NoError(t, c.Connect())
+	defer func() { _ = c.Close() }()
+
+	err = c.Receive()
+	require.ErrorIs(t, err, ErrIdleTimeout)
+}
@@ -0,0 +1,368 @@
+package telnet
+
+import (
+	"fmt"
+	"io"
+)
+
+// Telnet command bytes (RFC 854).
+const (
+	iacSE   byte = 240 // End of subnegotiation parameters.
+	iacNOP  byte = 241 // No operation.
+	iacDM   byte = 242 // Data mark.
+	iacBRK  byte = 243 // Break.
+	iacIP   byte = 244 // Interrupt process.
+	iacAO   byte = 245 // Abort output.
+	iacAYT  byte = 246 // Are you there.
+	iacEC   byte = 247 // Erase character.
+	iacEL   byte = 248 // Erase line.
+	iacGA   byte = 249 // Go ahead.
+	iacSB   byte = 250 // Subnegotiation begin.
+	iacWILL byte = 251
+	iacWONT byte = 252
+	iacDO   byte = 253
+	iacDONT byte = 254
+	iacIAC  byte = 255 // Interpret as command.
+)
+
+// Telnet option codes this package ships default handlers for (RFC 856/857/858/1091/1073).
+const (
+	optBinary byte = 0  // RFC 856.
+	optEcho   byte = 1  // RFC 857.
+	optSGA    byte = 3  // RFC 858, Suppress Go Ahead.
+	optTType  byte = 24 // RFC 1091, Terminal Type.
+	optNAWS   byte = 31 // RFC 1073, Negotiate About Window Size.
+)
+
+// OptionHandler reacts to telnet option negotiation and subnegotiation for a single option.
+//
+// OnWill/OnDo are called when the remote side offers to enable an option on itself/on us
+// respectively; returning true accepts the offer (a DO/WILL is sent back), false rejects it
+// (DONT/WONT is sent back).
+type OptionHandler interface {
+	OnWill(opt byte) (accept bool)
+	OnDo(opt byte) (accept bool)
+	OnSubnegotiation(opt byte, data []byte)
+}
+
+// qState is a Q Method negotiation state for one side of one option. Only NO/YES are tracked:
+// the WANTNO/WANTYES states (and their queued variants) that Dave Borman's "Telnet Option
+// Negotiation Q Method" defines for an in-flight, self-initiated request are not implemented.
+// The client only answers WILL/WONT/DO/DONT offered by the peer. Session.Negotiate does send
+// IAC DO itself, but that request is not recorded here, so the peer's WILL in answer is handled
+// as a fresh offer: the handler is consulted and, if it accepts, IAC DO is sent once more.
+type qState int
+
+const (
+	qNo qState = iota
+	qYes
+)
+
+// optionState tracks the Q Method state of one telnet option, separately for each side:
+// "us" is the state of the option on this client, "him" is the state of the option on the peer.
+type optionState struct {
+	us  qState
+	him qState
+}
+
+// negotiator implements the RFC 854/855 option negotiation state machine and strips/escapes
+// IAC sequences at the edges of the connection. It is not safe for concurrent use; callers
+// serialize access through the client's mutex.
+type negotiator struct {
+	options  map[byte]*optionState
+	handlers map[byte]OptionHandler
+
+	// Subnegotiation accumulator, used while parsing an IAC SB ... IAC SE sequence that may
+	// span several reads.
+	inSubneg bool
+	subOpt   byte
+	subBuf   []byte
+
+	// windowWidth/windowHeight hold the last size reported through a NAWS subnegotiation.
+	windowWidth  uint16
+	windowHeight uint16
+}
+
+// newNegotiator builds a negotiator with the given per-option handler overrides. Options
+// without an explicit handler fall back to this package's default handlers.
+func newNegotiator(handlers map[byte]OptionHandler) *negotiator {
+	merged := map[byte]OptionHandler{
+		optBinary: binaryHandler{},
+		optEcho:   echoHandler{},
+		optSGA:    sgaHandler{},
+		optTType:  ttypeHandler{},
+		optNAWS:   nil, // Filled in below: NAWS handler needs a back-reference to store size.
+	}
+	for opt, h := range handlers {
+		merged[opt] = h
+	}
+
+	n := &negotiator{
+		options:  make(map[byte]*optionState),
+		handlers: merged,
+	}
+	if merged[optNAWS] == nil {
+		n.handlers[optNAWS] = &nawsHandler{n: n}
+	}
+	return n
+}
+
+// stateFor returns the option's state, creating a fresh NO/NO entry on first use.
+func (n *negotiator) stateFor(opt byte) *optionState {
+	st, ok := n.options[opt]
+	if !ok {
+		st = &optionState{us: qNo, him: qNo}
+		n.options[opt] = st
+	}
+	return st
+}
+
+// handlerFor returns the handler registered for opt, or a rejectHandler if none is known.
+func (n *negotiator) handlerFor(opt byte) OptionHandler {
+	if h, ok := n.handlers[opt]; ok {
+		return h
+	}
+	return rejectHandler{}
+}
+
+// process scans data read from the connection, strips IAC sequences, answers negotiation
+// requests on w (the connection being read from), and returns the remaining user-visible bytes.
+func (n *negotiator) process(data []byte, w io.Writer) ([]byte, error) {
+	clean := make([]byte, 0, len(data))
+	replies := make([]byte, 0)
+
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+
+		if n.inSubneg {
+			if b == iacIAC && i+1 < len(data) && data[i+1] == iacSE {
+				n.handlerFor(n.subOpt).OnSubnegotiation(n.subOpt, n.subBuf)
+				n.inSubneg = false
+				n.subBuf = nil
+				i++
+				continue
+			}
+			if b == iacIAC && i+1 < len(data) && data[i+1] == iacIAC {
+				// An escaped literal 0xFF in the payload (e.g. a 255-column NAWS width):
+				// collapse the pair to a single byte, mirroring the main data stream below.
+				n.subBuf = append(n.subBuf, iacIAC)
+				i++
+				continue
+			}
+			n.subBuf = append(n.subBuf, b)
+			continue
+		}
+
+		if b != iacIAC {
+			clean = append(clean, b)
+			continue
+		}
+
+		// b == IAC: look at the next byte to decide what kind of command this is.
+		if i+1 >= len(data) {
+			// IAC arrived split across reads; nothing sane to do without buffering state
+			// across calls, so treat the trailing IAC as data discarded by the peer.
+			break
+		}
+		cmd := data[i+1]
+
+		switch cmd {
+		case iacIAC:
+			clean = append(clean, iacIAC)
+			i++
+		case iacWILL, iacWONT, iacDO, iacDONT:
+			if i+2 >= len(data) {
+				i++
+				break
+			}
+			opt := data[i+2]
+			replies = append(replies, n.negotiate(cmd, opt)...)
+			i += 2
+		case iacSB:
+			if i+2 >= len(data) {
+				i++
+				break
+			}
+			n.inSubneg = true
+			n.subOpt = data[i+2]
+			n.subBuf = n.subBuf[:0]
+			i += 2
+		case iacNOP, iacDM, iacAYT, iacGA:
+			i++
+		case iacIP, iacBRK, iacAO, iacEC, iacEL:
+			i++
+		default:
+			i++
+		}
+	}
+
+	if len(replies) > 0 {
+		if _, err := w.Write(replies); err != nil {
+			return clean, fmt.Errorf("unable to write negotiation reply: %w", err)
+		}
+	}
+
+	return clean, nil
+}
+
+// negotiate applies one incoming WILL/WONT/DO/DONT to the Q Method state machine for opt and
+// returns the IAC reply bytes (if any) that must be sent back.
+func (n *negotiator) negotiate(cmd, opt byte) []byte {
+	st := n.stateFor(opt)
+
+	switch cmd {
+	case iacWILL:
+		return n.negotiateSide(&st.him, opt, true)
+	case iacWONT:
+		return n.negotiateSideDisable(&st.him, opt, true)
+	case iacDO:
+		return n.negotiateSide(&st.us, opt, false)
+	case iacDONT:
+		return n.negotiateSideDisable(&st.us, opt, false)
+	}
+	return nil
+}
+
+// negotiateSide handles an incoming "enable" request (WILL from him, or DO for us) per the
+// Q Method, deciding via the registered handler whether to accept it. qYes is a no-op: a
+// repeated offer while already enabled is not answered, which prevents negotiation loops. In
+// qNo the offer is always treated as new (see qState).
+func (n *negotiator) negotiateSide(side *qState, opt byte, remoteEnabling bool) []byte {
+	if *side == qYes {
+		return nil
+	}
+
+	accept := func() bool {
+		if remoteEnabling {
+			return n.handlerFor(opt).OnWill(opt)
+		}
+		return n.handlerFor(opt).OnDo(opt)
+	}
+
+	accepted, rejected := enableReply(remoteEnabling)
+	if accept() {
+		*side = qYes
+		return accepted(opt)
+	}
+	return rejected(opt)
+}
+
+// negotiateSideDisable handles an incoming "disable" request (WONT from him, or DONT for us).
+// remoteEnabling selects which command (DONT/WONT) acknowledges the disable, mirroring
+// enableReply's direction convention.
+func (n *negotiator) negotiateSideDisable(side *qState, opt byte, remoteEnabling bool) []byte {
+	if *side == qNo {
+		return nil
+	}
+	_, rejected := enableReply(remoteEnabling)
+	*side = qNo
+	return rejected(opt)
+}
+
+// enableReply returns the accepted/rejected reply builders for an enable request, depending
+// on which side of the wire is being enabled.
+func enableReply(remoteEnabling bool) (accepted, rejected func(byte) []byte) {
+	if remoteEnabling {
+		return func(opt byte) []byte { return []byte{iacIAC, iacDO, opt} },
+			func(opt byte) []byte { return []byte{iacIAC, iacDONT, opt} }
+	}
+	return func(opt byte) []byte { return []byte{iacIAC, iacWILL, opt} },
+		func(opt byte) []byte { return []byte{iacIAC, iacWONT, opt} }
+}
+
+// escapeIAC doubles every 0xFF byte in data so it survives the wire as literal data instead
+// of being misread as the start of a telnet command.
+func escapeIAC(data []byte) []byte {
+	count := 0
+	for _, b := range data {
+		if b == iacIAC {
+			count++
+		}
+	}
+	if count == 0 {
+		return data
+	}
+
+	out := make([]byte, 0, len(data)+count)
+	for _, b := range data {
+		out = append(out, b)
+		if b == iacIAC {
+			out = append(out, iacIAC)
+		}
+	}
+	return out
+}
+
+// rejectHandler is used for options without a registered or default handler: it refuses to
+// enable anything and ignores subnegotiation data.
+type rejectHandler struct{}
+
+func (rejectHandler) OnWill(byte) bool              { return false }
+func (rejectHandler) OnDo(byte) bool                { return false }
+func (rejectHandler) OnSubnegotiation(byte, []byte) {}
+
+// binaryHandler accepts BINARY (RFC 856) in both directions.
+type binaryHandler struct{}
+
+func (binaryHandler) OnWill(byte) bool              { return true }
+func (binaryHandler) OnDo(byte) bool                { return true }
+func (binaryHandler) OnSubnegotiation(byte, []byte) {}
+
+// echoHandler accepts ECHO (RFC 857) in both directions.
+type echoHandler struct{}
+
+func (echoHandler) OnWill(byte) bool              { return true }
+func (echoHandler) OnDo(byte) bool                { return true }
+func (echoHandler) OnSubnegotiation(byte, []byte) {}
+
+// sgaHandler accepts SUPPRESS-GO-AHEAD (RFC 858) in both directions.
+type sgaHandler struct{}
+
+func (sgaHandler) OnWill(byte) bool              { return true }
+func (sgaHandler) OnDo(byte) bool                { return true }
+func (sgaHandler) OnSubnegotiation(byte, []byte) {}
+
+// ttypeHandler accepts TERMINAL-TYPE (RFC 1091) but does not answer subnegotiation queries,
+// since this package has no terminal type of its own to report.
+type ttypeHandler struct{}
+
+func (ttypeHandler) OnWill(byte) bool              { return true }
+func (ttypeHandler) OnDo(byte) bool                { return true }
+func (ttypeHandler) OnSubnegotiation(byte, []byte) {}
+
+// nawsHandler negotiates the NAWS option (RFC 1073) and records whatever window size the peer
+// sends in its SB NAWS subnegotiations, so it can be read back through Client.WindowSize.
+//
+// RFC 1073 has the client advertise its own terminal size to the server, not the other way
+// around; this package has no terminal of its own to report (it reads/writes arbitrary
+// io.Reader/io.Writer, not necessarily a TTY), so it never emits an SB NAWS of its own. What
+// WindowSize reports is therefore whatever size the remote side chooses to send, however it
+// chooses to use the option.
+type nawsHandler struct {
+	n *negotiator
+}
+
+func (nawsHandler) OnWill(byte) bool { return true }
+func (nawsHandler) OnDo(byte) bool   { return true }
+
+func (h *nawsHandler) OnSubnegotiation(_ byte, data []byte) {
+	if len(data) < 4 {
+		return
+	}
+	h.n.windowWidth = uint16(data[0])<<8 | uint16(data[1])
+	h.n.windowHeight = uint16(data[2])<<8 | uint16(data[3])
+}
+
+// WindowSize returns the size last sent by the peer in a NAWS (RFC 1073) subnegotiation; see
+// nawsHandler for why this is the peer's size rather than this client's own. ok is false if the
+// peer hasn't sent a size yet (or hasn't negotiated NAWS at all), in which case width/height are
+// zero.
+func (c *client) WindowSize() (width, height uint16, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.neg.windowWidth == 0 && c.neg.windowHeight == 0 {
+		return 0, 0, false
+	}
+	return c.neg.windowWidth, c.neg.windowHeight, true
+}
@@ -0,0 +1,175 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeIAC(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    []byte
+		expected []byte
+	}{
+		{
+			name:     "no IAC bytes",
+			input:    []byte("hello\n"),
+			expected: []byte("hello\n"),
+		},
+		{
+			name:     "single IAC byte",
+			input:    []byte{'a', iacIAC, 'b'},
+			expected: []byte{'a', iacIAC, iacIAC, 'b'},
+		},
+		{
+			name:     "only IAC bytes",
+			input:    []byte{iacIAC, iacIAC},
+			expected: []byte{iacIAC, iacIAC, iacIAC, iacIAC},
+		},
+		{
+			name:     "empty input",
+			input:    []byte{},
+			expected: []byte{},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			require.Equal(t, tC.expected, escapeIAC(tC.input))
+		})
+	}
+}
+
+func TestNegotiator_Process(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         []byte
+		expectedClean []byte
+		expectedReply []byte
+	}{
+		{
+			name:          "plain data passes through",
+			input:         []byte("hello\n"),
+			expectedClean: []byte("hello\n"),
+			expectedReply: nil,
+		},
+		{
+			name:          "escaped IAC is unescaped to a single byte",
+			input:         []byte{'a', iacIAC, iacIAC, 'b'},
+			expectedClean: []byte{'a', iacIAC, 'b'},
+			expectedReply: nil,
+		},
+		{
+			name:          "DO for a default-accepted option is answered with WILL",
+			input:         []byte{iacIAC, iacDO, optEcho},
+			expectedClean: []byte{},
+			expectedReply: []byte{iacIAC, iacWILL, optEcho},
+		},
+		{
+			name:          "DO for an unknown option is rejected with WONT",
+			input:         []byte{iacIAC, iacDO, 0x2A},
+			expectedClean: []byte{},
+			expectedReply: []byte{iacIAC, iacWONT, 0x2A},
+		},
+		{
+			name:          "WILL for a default-accepted option is answered with DO",
+			input:         []byte{iacIAC, iacWILL, optSGA},
+			expectedClean: []byte{},
+			expectedReply: []byte{iacIAC, iacDO, optSGA},
+		},
+		{
+			name:          "NOP is consumed without a reply",
+			input:         []byte{'x', iacIAC, iacNOP, 'y'},
+			expectedClean: []byte{'x', 'y'},
+			expectedReply: nil,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			n := newNegotiator(nil)
+			out := &bytes.Buffer{}
+
+			clean, err := n.process(tC.input, out)
+			require.NoError(t, err)
+			require.Equal(t, tC.expectedClean, clean)
+			require.Equal(t, tC.expectedReply, out.Bytes())
+		})
+	}
+}
+
+func TestNegotiator_NAWSSubnegotiation(t *testing.T) {
+	n := newNegotiator(nil)
+	out := &bytes.Buffer{}
+
+	data := []byte{iacIAC, iacSB, optNAWS, 0, 80, 0, 24, iacIAC, iacSE}
+	clean, err := n.process(data, out)
+	require.NoError(t, err)
+	require.Empty(t, clean)
+	require.Equal(t, uint16(80), n.windowWidth)
+	require.Equal(t, uint16(24), n.windowHeight)
+}
+
+func TestNegotiator_NAWSSubnegotiation_EscapedIAC(t *testing.T) {
+	n := newNegotiator(nil)
+	out := &bytes.Buffer{}
+
+	// A 255-column width is encoded as the byte pair 0x00 0xFF; the trailing 0xFF must be sent
+	// doubled (IAC IAC) so it isn't mistaken for the start of IAC SE, and unescaped back to a
+	// single byte here.
+	data := []byte{iacIAC, iacSB, optNAWS, 0, iacIAC, iacIAC, 0, 24, iacIAC, iacSE}
+	clean, err := n.process(data, out)
+	require.NoError(t, err)
+	require.Empty(t, clean)
+	require.Equal(t, uint16(255), n.windowWidth)
+	require.Equal(t, uint16(24), n.windowHeight)
+}
+
+func TestClient_WindowSize(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, acceptErr := l.Accept()
+		require.NoError(t, acceptErr)
+		accepted <- conn
+	}()
+
+	c := NewClient(l.Addr().String(), 5*time.Second, io.NopCloser(&bytes.Buffer{}), &bytes.Buffer{})
+	require.NoError(t, c.Connect())
+	defer func() { _ = c.Close() }()
+
+	serverConn := <-accepted
+	defer func() { _ = serverConn.Close() }()
+
+	_, _, ok := c.WindowSize()
+	require.False(t, ok, "no size reported yet")
+
+	// Resize to 80x24, then to 132x43, mirroring a client sending NAWS on connect and again on
+	// a later terminal resize. The second write only happens once the first has been consumed,
+	// so the two resizes can't land in the same read and get coalesced into one frame.
+	_, err = serverConn.Write([]byte{iacIAC, iacSB, optNAWS, 0, 80, 0, 24, iacIAC, iacSE})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Receive())
+	width, height, ok := c.WindowSize()
+	require.True(t, ok)
+	require.Equal(t, uint16(80), width)
+	require.Equal(t, uint16(24), height)
+
+	_, err = serverConn.Write([]byte{iacIAC, iacSB, optNAWS, 0, 132, 0, 43, iacIAC, iacSE})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Receive())
+	width, height, ok = c.WindowSize()
+	require.True(t, ok)
+	require.Equal(t, uint16(132), width)
+	require.Equal(t, uint16(43), height)
+}
@@ -0,0 +1,236 @@
+package telnet
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// defaultAcceptTimeout bounds how long Accept blocks between checks of ctx.Done() in
+// ListenAndServe.
+const defaultAcceptTimeout = time.Second
+
+// defaultShutdownGrace is how long ListenAndServe waits for in-flight sessions to finish on
+// their own once ctx is canceled, before force-closing what's left.
+const defaultShutdownGrace = 5 * time.Second
+
+// Session is a single accepted telnet connection, handed to a Server's handler function.
+type Session interface {
+	// Send writes data to the peer, escaping any IAC bytes it contains.
+	Send(data []byte) error
+	// ReadLine blocks until a full, negotiation-stripped line of user data is available.
+	ReadLine() ([]byte, error)
+	// Negotiate registers handler for opt and offers to enable it on the peer (IAC DO opt).
+	Negotiate(opt byte, handler OptionHandler) error
+	// RemoteAddr returns the peer's network address.
+	RemoteAddr() net.Addr
+}
+
+// session implements Session over a single accepted net.Conn, reusing the same option
+// negotiation state machine the client side uses.
+type session struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	neg    *negotiator
+
+	// pending holds negotiation-stripped bytes read ahead of the next '\n', since readFrame now
+	// returns whatever is currently available rather than a full line.
+	pending []byte
+}
+
+func newSession(conn net.Conn) *session {
+	return &session{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		neg:    newNegotiator(nil),
+	}
+}
+
+// Send implements Session.
+func (s *session) Send(data []byte) error {
+	return writeFrame(s.conn, escapeIAC(data))
+}
+
+// ReadLine implements Session.
+func (s *session) ReadLine() ([]byte, error) {
+	for {
+		if i := bytes.IndexByte(s.pending, '\n'); i >= 0 {
+			line := s.pending[:i+1]
+			s.pending = s.pending[i+1:]
+			return line, nil
+		}
+
+		data, err := readFrame(s.reader)
+		if err != nil {
+			return nil, err
+		}
+		clean, err := s.neg.process(data, s.conn)
+		if err != nil {
+			return nil, err
+		}
+		s.pending = append(s.pending, clean...)
+		// A frame with no clean bytes (pure negotiation traffic, e.g. a bare IAC DO) or one that
+		// didn't complete a line just loops back to read the next one.
+	}
+}
+
+// Negotiate implements Session.
+func (s *session) Negotiate(opt byte, handler OptionHandler) error {
+	s.neg.handlers[opt] = handler
+	return writeFrame(s.conn, []byte{iacIAC, iacDO, opt})
+}
+
+// RemoteAddr implements Session.
+func (s *session) RemoteAddr() net.Addr {
+	return s.conn.RemoteAddr()
+}
+
+// ServerOption configures optional Server behavior.
+type ServerOption func(*Server)
+
+// WithAcceptTimeout overrides how long ListenAndServe's accept loop waits between checks of
+// ctx.Done(). The default is 1 second.
+func WithAcceptTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.acceptTimeout = d
+	}
+}
+
+// WithShutdownGrace overrides how long ListenAndServe waits for in-flight sessions to finish on
+// their own after ctx is canceled, before force-closing the rest. The default is 5 seconds.
+func WithShutdownGrace(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.shutdownGrace = d
+	}
+}
+
+// Server accepts inbound telnet connections and runs a handler function against each one,
+// letting this package be used for test harnesses, honeypots, or embedded shells.
+type Server struct {
+	addr          string
+	handler       func(Session) error
+	acceptTimeout time.Duration
+	shutdownGrace time.Duration
+
+	mu       sync.Mutex
+	listener *net.TCPListener
+	sessions map[*session]struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer is a constructor for Server. It doesn't perform any validation of the input
+// parameters.
+func NewServer(addr string, handler func(Session) error, opts ...ServerOption) *Server {
+	s := &Server{
+		addr:          addr,
+		handler:       handler,
+		acceptTimeout: defaultAcceptTimeout,
+		shutdownGrace: defaultShutdownGrace,
+		sessions:      make(map[*session]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ListenAndServe listens on the server's address and spawns a goroutine running the handler
+// for each accepted connection. The accept loop polls a deadline instead of blocking in Accept
+// forever, so it can observe ctx.Done() between accepts. It returns once ctx is canceled and
+// in-flight sessions have either finished or been force-closed after the shutdown grace period.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("listen failed: %w", err)
+	}
+	tcpLn, ok := ln.(*net.TCPListener)
+	if !ok {
+		_ = ln.Close()
+		return fmt.Errorf("listener for %q is not a TCP listener", s.addr)
+	}
+
+	s.mu.Lock()
+	s.listener = tcpLn
+	s.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return s.shutdown()
+		default:
+		}
+
+		if err := tcpLn.SetDeadline(time.Now().Add(s.acceptTimeout)); err != nil {
+			return fmt.Errorf("unable to set accept deadline: %w", err)
+		}
+
+		conn, err := tcpLn.AcceptTCP()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue // Give the ctx.Done() check above another chance.
+			}
+			return fmt.Errorf("accept failed: %w", err)
+		}
+
+		s.serve(conn)
+	}
+}
+
+// serve registers a session for conn and runs the handler against it in its own goroutine.
+func (s *Server) serve(conn net.Conn) {
+	sess := newSession(conn)
+
+	s.mu.Lock()
+	s.sessions[sess] = struct{}{}
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.sessions, sess)
+			s.mu.Unlock()
+			_ = conn.Close()
+		}()
+		_ = s.handler(sess)
+	}()
+}
+
+// shutdown closes the listener so no new connections are accepted, then waits up to
+// shutdownGrace for in-flight sessions to finish before force-closing what's left.
+func (s *Server) shutdown() error {
+	s.mu.Lock()
+	ln := s.listener
+	s.mu.Unlock()
+	if ln != nil {
+		_ = ln.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(s.shutdownGrace):
+	}
+
+	s.mu.Lock()
+	for sess := range s.sessions {
+		_ = sess.conn.Close()
+	}
+	s.mu.Unlock()
+
+	<-done
+	return nil
+}
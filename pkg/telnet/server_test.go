@@ -0,0 +1,84 @@
+package telnet
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ListenAndServe(t *testing.T) {
+	t.Run("echoes a line and shuts down cleanly", func(t *testing.T) {
+		srv := NewServer("127.0.0.1:0", func(s Session) error {
+			line, err := s.ReadLine()
+			if err != nil {
+				return err
+			}
+			return s.Send(line)
+		}, WithAcceptTimeout(20*time.Millisecond))
+
+		addr, stop := startServer(t, srv)
+		defer stop()
+
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, conn.Close()) }()
+
+		_, err = conn.Write([]byte("hello\n"))
+		require.NoError(t, err)
+
+		buf := make([]byte, 1024)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, "hello\n", string(buf[:n]))
+	})
+
+	t.Run("shuts down when ctx is canceled", func(t *testing.T) {
+		srv := NewServer("127.0.0.1:0", func(s Session) error {
+			// Blocks on the peer until it closes the connection or the server force-closes it;
+			// startServer's probe connection closes at once, so ReadLine returns on EOF.
+			_, err := s.ReadLine()
+			return err
+		}, WithAcceptTimeout(10*time.Millisecond), WithShutdownGrace(50*time.Millisecond))
+
+		_, stop := startServer(t, srv)
+		stop()
+	})
+}
+
+// startServer launches srv.ListenAndServe in the background on an ephemeral port and returns
+// the chosen address plus a function that cancels the context and waits for shutdown.
+func startServer(t *testing.T, srv *Server) (string, func()) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	srv.addr = addr
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.ListenAndServe(ctx) }()
+
+	require.Eventually(t, func() bool {
+		conn, dialErr := net.Dial("tcp", addr)
+		if dialErr != nil {
+			return false
+		}
+		defer conn.Close()
+		return true
+	}, time.Second, 5*time.Millisecond)
+
+	return addr, func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("server did not shut down in time")
+		}
+	}
+}
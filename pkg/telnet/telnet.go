@@ -6,7 +6,20 @@
 //
 // - EOT signal;
 //
-// - connection closing.
+// - connection closing;
+//
+// - RFC 854/855 telnet option negotiation (IAC), with per-option handlers registerable via
+// NewClientWithOptions;
+//
+// - implicit TLS (telnets) and in-band STARTTLS upgrades (RFC 2946), via WithTLS/WithStartTLS;
+//
+// - application-level keep-alive (WithKeepAlive/WithAYTKeepAlive) and idle-link detection
+// (WithIdleTimeout);
+//
+// - per-call deadlines via SendContext/ReceiveContext, and half-close via CloseRead/CloseWrite.
+//
+// The package also ships a Server type for accepting inbound telnet connections, sharing the
+// same option-negotiation state machine with the client side.
 //
 // Main client features are:
 //
@@ -17,6 +30,8 @@ package telnet
 
 import (
 	"bufio"
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -30,6 +45,10 @@ var (
 	ErrEOT = errors.New("EOT signal received")
 	// ErrConnClosed is used in an error chain when the connection to work with is closed.
 	ErrConnClosed = errors.New("connection is closed")
+	// ErrKeepAliveTimeout is used in an error chain when a keep-alive probe went unanswered.
+	ErrKeepAliveTimeout = errors.New("keep-alive timeout: no data received from server")
+	// ErrIdleTimeout is used in an error chain when no data was received within the idle timeout.
+	ErrIdleTimeout = errors.New("idle timeout: no data received from server")
 )
 
 // Client is an interface for a telnet client.
@@ -38,6 +57,22 @@ type Client interface {
 	io.Closer
 	Send() error
 	Receive() error
+	// SendContext behaves like Send, honoring ctx's deadline for the write and unblocking
+	// in-flight I/O when ctx is canceled.
+	SendContext(ctx context.Context) error
+	// ReceiveContext behaves like Receive, honoring ctx's deadline for the read and unblocking
+	// in-flight I/O when ctx is canceled.
+	ReceiveContext(ctx context.Context) error
+	// CloseRead closes the read half of the connection without tearing down the write half, if
+	// the underlying connection supports half-close.
+	CloseRead() error
+	// CloseWrite closes the write half of the connection without tearing down the read half, if
+	// the underlying connection supports half-close.
+	CloseWrite() error
+	// WindowSize returns the terminal size last sent by the peer through a NAWS (RFC 1073)
+	// subnegotiation; this client has no terminal of its own to advertise, so it only ever
+	// reports what the peer sends. ok is false if the peer has not sent a size yet.
+	WindowSize() (width, height uint16, ok bool)
 }
 
 // Client is used for storing internal client fields.
@@ -49,30 +84,102 @@ type client struct {
 	in      io.ReadCloser
 	out     io.Writer
 	conn    net.Conn
+	neg     *negotiator
+
+	// reader/inReader are persistent, one per source, so bytes read ahead into a bufio.Reader's
+	// internal buffer on one call are still there on the next instead of being discarded.
+	reader   *bufio.Reader
+	inReader *bufio.Reader
+
+	tlsConfig       *tls.Config
+	startTLS        bool
+	pendingStartTLS bool
+
+	keepAliveInterval time.Duration
+	keepAliveTimeout  time.Duration
+	aytKeepAlive      bool
+	idleTimeout       time.Duration
+
+	stopKeepAlive chan struct{}
+	recvNotify    chan struct{}
+	keepAliveErr  error
 }
 
 // NewClient is a constructor for Client.
 // It doesn't perform any validation of the input parameters.
-func NewClient(address string, timeout time.Duration, in io.ReadCloser, out io.Writer) Client {
-	return &client{
+func NewClient(address string, timeout time.Duration, in io.ReadCloser, out io.Writer, opts ...ClientOption) Client {
+	c := &client{
 		address: address,
 		timeout: timeout,
 		in:      in,
 		out:     out,
+		neg:     newNegotiator(nil),
+	}
+	if in != nil {
+		c.inReader = bufio.NewReader(in)
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
-// Connect connects to the server with a given timeout.
+// NewClientWithOptions is a constructor for Client that additionally registers custom
+// OptionHandler implementations, keyed by telnet option code. Options without an entry in
+// handlers fall back to this package's default handlers (ECHO, SUPPRESS-GO-AHEAD, NAWS,
+// TERMINAL-TYPE, BINARY); options with neither a custom nor a default handler are rejected.
+func NewClientWithOptions(
+	address string,
+	timeout time.Duration,
+	in io.ReadCloser,
+	out io.Writer,
+	handlers map[byte]OptionHandler,
+	opts ...ClientOption,
+) Client {
+	c := &client{
+		address: address,
+		timeout: timeout,
+		in:      in,
+		out:     out,
+		neg:     newNegotiator(handlers),
+	}
+	if in != nil {
+		c.inReader = bufio.NewReader(in)
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Connect connects to the server with a given timeout. If a TLS configuration was set via
+// WithTLS, the connection is TLS from the first byte (telnets); if WithStartTLS was set
+// instead, Connect dials plain TCP and the upgrade happens later, in-band, once the server
+// negotiates the START_TLS telnet option.
 func (c *client) Connect() error {
 	c.mu.RLock()
-	address, timeout := c.address, c.timeout
+	address, timeout, tlsConfig, startTLS := c.address, c.timeout, c.tlsConfig, c.startTLS
 	c.mu.RUnlock() // To avoid blocking while dialing with timeout.
-	conn, err := net.DialTimeout("tcp", address, timeout)
+
+	var conn net.Conn
+	var err error
+	if tlsConfig != nil && !startTLS {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", address, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", address, timeout)
+	}
 	if err != nil {
 		return fmt.Errorf("connection failed: %w", err)
 	}
+
 	c.mu.Lock()
 	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+	if c.keepAliveInterval > 0 {
+		c.stopKeepAlive = make(chan struct{})
+		c.recvNotify = make(chan struct{}, 1)
+		go c.keepAliveLoop()
+	}
 	c.mu.Unlock()
 	return nil
 }
@@ -82,6 +189,11 @@ func (c *client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.stopKeepAlive != nil {
+		close(c.stopKeepAlive)
+		c.stopKeepAlive = nil
+	}
+
 	var err error
 	switch {
 	case c.conn != nil && c.in != nil:
@@ -101,80 +213,67 @@ func (c *client) Close() error {
 	return err
 }
 
-// Send sends data received from the input stream to the server.
+// Send sends data received from the input stream to the server. It is equivalent to
+// SendContext with a context.Background(), i.e. without a deadline.
 func (c *client) Send() error {
-	c.mu.RLock()
-	if c.conn == nil || c.in == nil {
-		return fmt.Errorf("nil parameter received: connection=%v, input_stream=%v", c.conn == nil, c.in == nil)
-	}
-	c.mu.RUnlock()
-
-	data, err := c.readOut(c.in)
-	if err != nil {
-		return err
-	}
-	// CTRL+D case.
-	if len(data) == 0 {
-		return ErrEOT
-	}
+	return c.SendContext(context.Background())
+}
 
-	err = c.writeOut(c.conn, data)
-	if err != nil {
-		return err
-	}
+// Receive reads data from the server and writes it to the output stream. It is equivalent to
+// ReceiveContext with a context.Background(), i.e. without a deadline.
+func (c *client) Receive() error {
+	return c.ReceiveContext(context.Background())
+}
 
-	return nil
+// readOut is a thin wrapper around readFrame, kept as a method for call-site symmetry with
+// writeOut.
+func (c *client) readOut(reader *bufio.Reader) ([]byte, error) {
+	return readFrame(reader)
 }
 
-// Receive reads data from the server and writes it to the output stream.
-func (c *client) Receive() error {
-	c.mu.RLock()
-	if c.conn == nil || c.out == nil {
-		return fmt.Errorf("nil parameter received: connection=%v, output_stream=%v", c.conn == nil, c.out == nil)
-	}
-	c.mu.RUnlock()
+// readFrameBufSize is the chunk size readFrame asks the reader for. Telnet negotiation replies
+// must be sent as soon as the bytes that trigger them arrive, so readFrame cannot wait for a
+// line or block delimiter the peer may never send; this is just big enough to avoid repeated
+// syscalls for a typical line of user data.
+const readFrameBufSize = 4096
 
-	data, err := c.readOut(c.conn)
-	if err != nil {
-		return err
+// readFrame is a universal reader which works with a persistent *bufio.Reader: reusing the same
+// reader across calls (instead of wrapping the source fresh each time) keeps bytes it reads
+// ahead into its internal buffer available to the next call instead of discarding them. It is
+// shared by the client and the server-side Session.
+//
+// It returns whatever bytes are currently available rather than waiting for a line or block
+// delimiter: telnet option negotiation is framed by IAC sequences, not newlines, so a peer that
+// sends IAC DO/WILL and then waits for the reply before sending more data would otherwise never
+// be answered. Callers that need line-buffered semantics over the negotiation-stripped result
+// (e.g. Session.ReadLine) do their own buffering on top of this.
+func readFrame(reader *bufio.Reader) ([]byte, error) {
+	buf := make([]byte, readFrameBufSize)
+	n, err := reader.Read(buf)
+	if n > 0 {
+		return buf[:n], nil
 	}
-
-	err = c.writeOut(c.out, data)
 	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// readOut is a universal reader which works with io.Reader interface.
-func (c *client) readOut(r io.Reader) ([]byte, error) {
-	reader := bufio.NewReader(r)
-	var res []byte
-	for {
-		line, err := reader.ReadBytes('\n')
-		res = append(res, line...)
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				if reader.Buffered() == 0 {
-					return nil, ErrEOT // No more data to read.
-				}
-				continue // More data in buffer, trying again.
-			}
-			if errors.Is(err, net.ErrClosed) {
-				return nil, fmt.Errorf("%w: %w", ErrConnClosed, err)
-			}
-			return nil, fmt.Errorf("reading failed: %w", err)
+		if errors.Is(err, io.EOF) {
+			return nil, ErrEOT
 		}
-		if reader.Buffered() == 0 {
-			break // Not expecting any more data here.
+		if errors.Is(err, net.ErrClosed) {
+			return nil, fmt.Errorf("%w: %w", ErrConnClosed, err)
 		}
+		return nil, fmt.Errorf("reading failed: %w", err)
 	}
-	return res, nil
+	return nil, nil
 }
 
-// writeOut is a universal writer which writes data to provided writer.
+// writeOut is a thin wrapper around writeFrame, kept as a method for call-site symmetry with
+// readOut.
 func (c *client) writeOut(w io.Writer, data []byte) error {
+	return writeFrame(w, data)
+}
+
+// writeFrame is a universal writer which writes data to the provided writer. It is shared by
+// the client and the server-side Session.
+func writeFrame(w io.Writer, data []byte) error {
 	_, err := w.Write(data)
 	if err != nil {
 		if errors.Is(err, net.ErrClosed) {
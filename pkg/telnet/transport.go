@@ -0,0 +1,138 @@
+package telnet
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+)
+
+// optStartTLS is the telnet START_TLS option code (RFC 2946).
+const optStartTLS byte = 46
+
+// startTLSFollows is the only defined START_TLS subnegotiation parameter: it tells the peer
+// that the TLS handshake begins immediately after this subnegotiation.
+const startTLSFollows byte = 1
+
+// ClientOption configures optional client behavior. Options are applied in NewClient/
+// NewClientWithOptions, in the order given.
+type ClientOption func(*client)
+
+// WithTLS makes Connect dial with implicit TLS (telnets), using cfg for the handshake. A nil
+// cfg is treated as &tls.Config{}. Combine with WithStartTLS instead if the server expects a
+// plain-text connection that later upgrades in-band.
+func WithTLS(cfg *tls.Config) ClientOption {
+	return func(c *client) {
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		c.tlsConfig = cfg
+	}
+}
+
+// WithStartTLS makes Connect dial plain TCP and upgrades the connection to TLS in-band once
+// the server offers the START_TLS telnet option (RFC 2946). Use WithServerName/
+// WithInsecureSkipVerify alongside it to shape the handshake's tls.Config.
+func WithStartTLS() ClientOption {
+	return func(c *client) {
+		c.startTLS = true
+		if c.tlsConfig == nil {
+			c.tlsConfig = &tls.Config{}
+		}
+		c.neg.handlers[optStartTLS] = &startTLSHandler{c: c}
+	}
+}
+
+// WithServerName sets the server name used for TLS certificate verification and SNI. It
+// creates a TLS configuration if WithTLS/WithStartTLS has not already set one.
+func WithServerName(name string) ClientOption {
+	return func(c *client) {
+		if c.tlsConfig == nil {
+			c.tlsConfig = &tls.Config{}
+		}
+		c.tlsConfig.ServerName = name
+	}
+}
+
+// WithInsecureSkipVerify controls whether the TLS handshake verifies the server's certificate
+// chain and host name. It creates a TLS configuration if one has not already been set.
+func WithInsecureSkipVerify(skip bool) ClientOption {
+	return func(c *client) {
+		if c.tlsConfig == nil {
+			c.tlsConfig = &tls.Config{}
+		}
+		c.tlsConfig.InsecureSkipVerify = skip //nolint:gosec // opt-in, caller requested it explicitly.
+	}
+}
+
+// startTLSHandler reacts to the server offering the START_TLS option and flags the upgrade as
+// pending; the client performs the actual handshake once the negotiation reply has been
+// flushed, since OptionHandler has no direct access to the connection.
+type startTLSHandler struct {
+	c *client
+}
+
+// OnWill runs under c.mu, held by the caller of neg.process (ReceiveContext): it must not
+// re-acquire the lock, only set the field directly.
+func (h *startTLSHandler) OnWill(byte) bool {
+	h.c.pendingStartTLS = true
+	return true
+}
+
+func (h *startTLSHandler) OnDo(byte) bool { return false } // This client doesn't act as a START_TLS server.
+
+func (h *startTLSHandler) OnSubnegotiation(byte, []byte) {}
+
+// upgradeToTLS announces START_TLS FOLLOWS and performs the TLS handshake over the existing
+// connection, replacing c.conn/c.reader on success. Any bytes already buffered by the old
+// bufio.Reader are replayed into the handshake first, since they were read from the connection
+// before the upgrade and would otherwise be lost.
+func (c *client) upgradeToTLS() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.conn.Write([]byte{iacIAC, iacSB, optStartTLS, startTLSFollows, iacIAC, iacSE}); err != nil {
+		return fmt.Errorf("unable to announce START_TLS follows: %w", err)
+	}
+
+	cfg := c.tlsConfig.Clone()
+	if cfg.ServerName == "" {
+		if host, _, err := net.SplitHostPort(c.address); err == nil {
+			cfg.ServerName = host
+		}
+	}
+
+	var underlying net.Conn = c.conn
+	if n := c.reader.Buffered(); n > 0 {
+		buf, err := c.reader.Peek(n)
+		if err != nil {
+			return fmt.Errorf("unable to drain buffered data before TLS upgrade: %w", err)
+		}
+		underlying = &prefixedConn{Conn: c.conn, prefix: bytes.NewReader(append([]byte(nil), buf...))}
+	}
+
+	tlsConn := tls.Client(underlying, cfg)
+	if err := tlsConn.HandshakeContext(context.Background()); err != nil {
+		return fmt.Errorf("TLS handshake failed: %w", err)
+	}
+
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// prefixedConn wraps a net.Conn so that already-buffered bytes are served before reads fall
+// through to the underlying connection.
+type prefixedConn struct {
+	net.Conn
+	prefix *bytes.Reader
+}
+
+func (p *prefixedConn) Read(b []byte) (int, error) {
+	if p.prefix.Len() > 0 {
+		return p.prefix.Read(b)
+	}
+	return p.Conn.Read(b)
+}
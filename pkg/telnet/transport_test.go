@@ -0,0 +1,144 @@
+package telnet
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientOptions(t *testing.T) {
+	t.Run("WithTLS sets a TLS config", func(t *testing.T) {
+		cfg := &tls.Config{ServerName: "example.com"}
+		c := NewClient("localhost:0", 0, nil, nil, WithTLS(cfg)).(*client)
+		require.Same(t, cfg, c.tlsConfig)
+		require.False(t, c.startTLS)
+	})
+
+	t.Run("WithTLS with nil config falls back to an empty one", func(t *testing.T) {
+		c := NewClient("localhost:0", 0, nil, nil, WithTLS(nil)).(*client)
+		require.NotNil(t, c.tlsConfig)
+	})
+
+	t.Run("WithStartTLS marks startTLS and registers a handler", func(t *testing.T) {
+		c := NewClient("localhost:0", 0, nil, nil, WithStartTLS()).(*client)
+		require.True(t, c.startTLS)
+		require.NotNil(t, c.tlsConfig)
+		_, ok := c.neg.handlers[optStartTLS].(*startTLSHandler)
+		require.True(t, ok)
+	})
+
+	t.Run("WithServerName creates a config if needed", func(t *testing.T) {
+		c := NewClient("localhost:0", 0, nil, nil, WithServerName("example.com")).(*client)
+		require.Equal(t, "example.com", c.tlsConfig.ServerName)
+	})
+
+	t.Run("WithInsecureSkipVerify creates a config if needed", func(t *testing.T) {
+		c := NewClient("localhost:0", 0, nil, nil, WithInsecureSkipVerify(true)).(*client)
+		require.True(t, c.tlsConfig.InsecureSkipVerify)
+	})
+}
+
+func TestPrefixedConn_Read(t *testing.T) {
+	rest := io.NopCloser(bytes.NewBufferString("tail"))
+	pc := &prefixedConn{Conn: &readOnlyConn{r: rest}, prefix: bytes.NewReader([]byte("head-"))}
+
+	buf := make([]byte, 64)
+	n, err := pc.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "head-", string(buf[:n]))
+
+	n, err = pc.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "tail", string(buf[:n]))
+}
+
+// readOnlyConn adapts an io.Reader to net.Conn for tests that only exercise Read.
+type readOnlyConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *readOnlyConn) Read(b []byte) (int, error) { return c.r.Read(b) }
+
+func TestClient_StartTLS_Upgrade(t *testing.T) {
+	cert := newSelfSignedCert(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	serverErrCh := make(chan error, 1)
+	go func() {
+		serverErrCh <- func() error {
+			conn, err := l.Accept()
+			if err != nil {
+				return err
+			}
+			defer func() { _ = conn.Close() }()
+
+			// Offer START_TLS and wait for the client's acknowledgment.
+			if _, err := conn.Write([]byte{iacIAC, iacWILL, optStartTLS}); err != nil {
+				return err
+			}
+			buf := make([]byte, 3)
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				return err
+			}
+
+			// Read the FOLLOWS subnegotiation, then hand the raw conn to a TLS server.
+			sb := make([]byte, 6)
+			if _, err := io.ReadFull(conn, sb); err != nil {
+				return err
+			}
+			tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}})
+			return tlsConn.HandshakeContext(context.Background())
+		}()
+	}()
+
+	c := NewClient(l.Addr().String(), 5*time.Second, io.NopCloser(&bytes.Buffer{}), &bytes.Buffer{},
+		WithStartTLS(), WithInsecureSkipVerify(true)).(*client)
+	require.NoError(t, c.Connect())
+	defer func() { _ = c.Close() }()
+
+	require.NoError(t, c.ReceiveContext(context.Background()))
+	require.NoError(t, <-serverErrCh)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.conn.(*tls.Conn)
+	require.True(t, ok, "connection should have been upgraded to TLS")
+}
+
+// newSelfSignedCert generates an ephemeral self-signed certificate for TLS tests.
+func newSelfSignedCert(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+}